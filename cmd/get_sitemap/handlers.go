@@ -0,0 +1,274 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jempe/sitemap_scanner/internal/cache"
+	sitemapscanner "github.com/jempe/sitemap_scanner/sitemap_scanner"
+)
+
+// handleHealthz reports that the process is up, for liveness probes.
+func (app *application) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	apiResponse(w, http.StatusOK, map[string]string{
+		"status":  "available",
+		"version": version,
+	})
+}
+
+// handleReadyz reports that the process is ready to take traffic, for
+// readiness probes. The cache backend is initialized before the server
+// starts accepting connections, so readiness always follows liveness.
+func (app *application) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	apiResponse(w, http.StatusOK, map[string]string{
+		"status": "ready",
+	})
+}
+
+type SitemapRequest struct {
+	URL          string   `json:"url"`
+	RefreshCache bool     `json:"refresh_cache"`
+	Workers      int      `json:"workers"`
+	MaxDepth     int      `json:"max_depth"`
+	MaxURLs      int      `json:"max_urls"`
+	Since        string   `json:"since,omitempty"`
+	Until        string   `json:"until,omitempty"`
+	PathRegex    string   `json:"path_regex,omitempty"`
+	MinPriority  float64  `json:"min_priority,omitempty"`
+	ChangeFreqIn []string `json:"changefreq,omitempty"`
+}
+
+func (app *application) handleGetSitemap(w http.ResponseWriter, r *http.Request) {
+	// Parse JSON request
+	var req SitemapRequest
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&req); err != nil {
+		errMessage := map[string]string{
+			"error": "Invalid JSON",
+		}
+		apiResponse(w, http.StatusBadRequest, errMessage)
+		return
+	}
+
+	// Validate URL
+	if req.URL == "" {
+		errMessage := map[string]string{
+			"error": "URL is required",
+		}
+		apiResponse(w, http.StatusBadRequest, errMessage)
+		return
+	}
+
+	filter, err := buildFilter(req)
+	if err != nil {
+		errMessage := map[string]string{
+			"error": err.Error(),
+		}
+		apiResponse(w, http.StatusBadRequest, errMessage)
+		return
+	}
+
+	// Key the cache on every field that affects the result, not just the
+	// URL, so a request with a filter or crawl limits never gets served
+	// the cached response for a differently-scoped request to the same URL.
+	key := cacheKey(req)
+
+	// Load whatever is cached, if anything, so an unchanged sitemap can be
+	// revalidated with a conditional GET instead of being re-parsed.
+	var prior sitemapscanner.SitemapResult
+	havePrior := false
+	if entry, found, err := app.cache.Get(key); err == nil && found {
+		if err := json.Unmarshal(entry.Value, &prior); err == nil {
+			havePrior = true
+		}
+	}
+
+	if !req.RefreshCache && havePrior {
+		app.metrics.cacheHits.Inc()
+		app.logger.PrintInfo("Cache hit for URL", map[string]string{
+			"url": req.URL,
+		})
+		apiResponse(w, http.StatusOK, map[string]any{
+			"sitemap": prior,
+		})
+		return
+	}
+	app.metrics.cacheMisses.Inc()
+
+	if req.RefreshCache {
+		app.logger.PrintInfo("Refreshing cache for URL", map[string]string{
+			"url": req.URL,
+		})
+	} else {
+		app.logger.PrintInfo("Cache miss for URL, fetching sitemap", map[string]string{
+			"url": req.URL,
+		})
+	}
+
+	opts := sitemapscanner.DefaultGetSitemapOptions()
+	opts.Workers = req.Workers
+	opts.MaxDepth = req.MaxDepth
+	opts.MaxURLs = req.MaxURLs
+	opts.Filter = filter
+	if havePrior {
+		opts.Prior = prior.Revalidation
+	}
+
+	app.metrics.inFlightScans.Inc()
+	start := time.Now()
+	sitemapData, err := sitemapscanner.GetSitemapWithOptions(req.URL, opts)
+	app.metrics.fetchDuration.Observe(time.Since(start).Seconds())
+	app.metrics.inFlightScans.Dec()
+
+	if err != nil {
+		errMessage := map[string]string{
+			"error": err.Error(),
+		}
+		apiResponse(w, http.StatusInternalServerError, errMessage)
+		return
+	}
+
+	// Store in cache for cacheTTL
+	body, err := json.Marshal(sitemapData)
+	if err != nil {
+		errMessage := map[string]string{
+			"error": err.Error(),
+		}
+		apiResponse(w, http.StatusInternalServerError, errMessage)
+		return
+	}
+	if err := app.cache.Set(key, cache.Entry{Value: body, StoredAt: time.Now()}, cacheTTL); err != nil {
+		app.logger.PrintInfo("Failed to store sitemap in cache", map[string]string{
+			"url":   req.URL,
+			"error": err.Error(),
+		})
+	}
+
+	// Return success response
+	apiResponse(w, http.StatusOK, map[string]any{
+		"sitemap": sitemapData,
+	})
+}
+
+// BuildSitemapRequest is the body of a POST to /build-sitemap.
+type BuildSitemapRequest struct {
+	URLs    []sitemapscanner.SitemapURL `json:"urls"`
+	BaseURL string                      `json:"base_url,omitempty"`
+	Gzip    bool                        `json:"gzip,omitempty"`
+}
+
+func (app *application) handleBuildSitemap(w http.ResponseWriter, r *http.Request) {
+	var req BuildSitemapRequest
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&req); err != nil {
+		errMessage := map[string]string{
+			"error": "Invalid JSON",
+		}
+		apiResponse(w, http.StatusBadRequest, errMessage)
+		return
+	}
+
+	if len(req.URLs) == 0 {
+		errMessage := map[string]string{
+			"error": "urls is required",
+		}
+		apiResponse(w, http.StatusBadRequest, errMessage)
+		return
+	}
+
+	files, err := sitemapscanner.BuildSitemap(req.URLs, sitemapscanner.BuildOptions{
+		BaseURL: req.BaseURL,
+		Gzip:    req.Gzip,
+	})
+	if err != nil {
+		errMessage := map[string]string{
+			"error": err.Error(),
+		}
+		apiResponse(w, http.StatusInternalServerError, errMessage)
+		return
+	}
+
+	if len(files) == 1 {
+		w.Header().Set("Content-Type", "application/xml")
+		if req.Gzip {
+			w.Header().Set("Content-Encoding", "gzip")
+		}
+		w.Write(files[0].Data)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="sitemaps.zip"`)
+
+	zipWriter := zip.NewWriter(w)
+	for _, file := range files {
+		fileWriter, err := zipWriter.Create(file.Name)
+		if err != nil {
+			return
+		}
+		if _, err := fileWriter.Write(file.Data); err != nil {
+			return
+		}
+	}
+	zipWriter.Close()
+}
+
+// cacheKey derives a cache key from the target URL and every field of
+// req that affects the resulting SitemapResult (crawl limits and
+// filters), so two requests for the same URL with different options
+// never collide on the same cache entry.
+func cacheKey(req SitemapRequest) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "url=%s\n", req.URL)
+	fmt.Fprintf(h, "workers=%d\n", req.Workers)
+	fmt.Fprintf(h, "max_depth=%d\n", req.MaxDepth)
+	fmt.Fprintf(h, "max_urls=%d\n", req.MaxURLs)
+	fmt.Fprintf(h, "since=%s\n", req.Since)
+	fmt.Fprintf(h, "until=%s\n", req.Until)
+	fmt.Fprintf(h, "path_regex=%s\n", req.PathRegex)
+	fmt.Fprintf(h, "min_priority=%g\n", req.MinPriority)
+	fmt.Fprintf(h, "changefreq=%s\n", strings.Join(req.ChangeFreqIn, ","))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// buildFilter turns the filter fields of a SitemapRequest into a
+// sitemapscanner.SitemapFilter, rejecting unparseable dates or regex.
+func buildFilter(req SitemapRequest) (sitemapscanner.SitemapFilter, error) {
+	var filter sitemapscanner.SitemapFilter
+
+	if req.Since != "" {
+		since, err := time.Parse(time.RFC3339, req.Since)
+		if err != nil {
+			return filter, fmt.Errorf("invalid since: %v", err)
+		}
+		filter.Since = since
+	}
+
+	if req.Until != "" {
+		until, err := time.Parse(time.RFC3339, req.Until)
+		if err != nil {
+			return filter, fmt.Errorf("invalid until: %v", err)
+		}
+		filter.Until = until
+	}
+
+	if req.PathRegex != "" {
+		pathRegex, err := regexp.Compile(req.PathRegex)
+		if err != nil {
+			return filter, fmt.Errorf("invalid path_regex: %v", err)
+		}
+		filter.PathRegex = pathRegex
+	}
+
+	filter.MinPriority = req.MinPriority
+	filter.ChangeFreqIn = req.ChangeFreqIn
+
+	return filter, nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// apiResponse writes message as a JSON body with the given status code.
+func apiResponse(w http.ResponseWriter, status int, message any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(message)
+}
+
+// serverError logs err, tagged with the request that triggered it, and
+// responds with a generic 500 so internal details never reach the client.
+func (app *application) serverError(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.PrintError(err, map[string]string{
+		"request_id": requestIDFromContext(r.Context()),
+		"method":     r.Method,
+		"url":        r.URL.String(),
+	})
+
+	apiResponse(w, http.StatusInternalServerError, map[string]string{
+		"error": "the server encountered a problem and could not process your request",
+	})
+}
+
+// rateLimitExceeded responds 429 to a client that tripped the per-IP
+// rate limiter.
+func (app *application) rateLimitExceeded(w http.ResponseWriter, r *http.Request) {
+	apiResponse(w, http.StatusTooManyRequests, map[string]string{
+		"error": "rate limit exceeded",
+	})
+}
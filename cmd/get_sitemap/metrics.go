@@ -0,0 +1,42 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// metrics holds the Prometheus collectors exposed on /metrics, covering
+// the things an operator actually wants to alert on: is the cache
+// earning its keep, how long do scans take, and how many are running
+// right now.
+type metrics struct {
+	cacheHits     prometheus.Counter
+	cacheMisses   prometheus.Counter
+	fetchDuration prometheus.Histogram
+	inFlightScans prometheus.Gauge
+}
+
+// newMetrics registers and returns the collectors used throughout the
+// application. It must be called at most once per process, since
+// promauto registers each collector with the default registry.
+func newMetrics() *metrics {
+	return &metrics{
+		cacheHits: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "sitemap_scanner_cache_hits_total",
+			Help: "Total number of /get-sitemap requests served from cache.",
+		}),
+		cacheMisses: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "sitemap_scanner_cache_misses_total",
+			Help: "Total number of /get-sitemap requests that required a fresh scan.",
+		}),
+		fetchDuration: promauto.NewHistogram(prometheus.HistogramOpts{
+			Name:    "sitemap_scanner_fetch_duration_seconds",
+			Help:    "Time spent fetching and parsing a site's sitemaps.",
+			Buckets: prometheus.DefBuckets,
+		}),
+		inFlightScans: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "sitemap_scanner_in_flight_scans",
+			Help: "Number of /get-sitemap scans currently being processed.",
+		}),
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// routes assembles the application's handlers behind its middleware
+// stack. Basic auth is applied only to /get-sitemap and /build-sitemap, so
+// /healthz, /readyz, and /metrics stay reachable for load balancers
+// and scrapers that don't carry credentials.
+func (app *application) routes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /healthz", app.handleHealthz)
+	mux.HandleFunc("GET /readyz", app.handleReadyz)
+	mux.Handle("GET /metrics", promhttp.Handler())
+
+	var getSitemap http.Handler = http.HandlerFunc(app.handleGetSitemap)
+	var buildSitemap http.Handler = http.HandlerFunc(app.handleBuildSitemap)
+	if app.config.username != "" && app.config.password != "" {
+		getSitemap = app.basicAuth(getSitemap)
+		buildSitemap = app.basicAuth(buildSitemap)
+	}
+	mux.Handle("POST /get-sitemap", getSitemap)
+	mux.Handle("POST /build-sitemap", buildSitemap)
+
+	return app.recoverPanic(app.logAccess(app.enableCORS(app.rateLimit(mux))))
+}
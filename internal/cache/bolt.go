@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var sitemapBucket = []byte("sitemaps")
+
+// Bolt is a SitemapCache backed by a single BoltDB file, so cached
+// results survive a restart without needing a separate service.
+type Bolt struct {
+	db     *bolt.DB
+	hits   int64
+	misses int64
+}
+
+// NewBolt opens (creating if necessary) a BoltDB file at path.
+func NewBolt(path string) (*Bolt, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(sitemapBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Bolt{db: db}, nil
+}
+
+func (b *Bolt) Get(key string) (Entry, bool, error) {
+	var entry Entry
+	found := false
+
+	err := b.db.View(func(tx *bolt.Tx) error {
+		body := tx.Bucket(sitemapBucket).Get([]byte(key))
+		if body == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(body, &entry)
+	})
+	if err != nil {
+		return Entry{}, false, err
+	}
+
+	if !found {
+		atomic.AddInt64(&b.misses, 1)
+		return Entry{}, false, nil
+	}
+
+	if !entry.ExpiresAt.IsZero() && time.Now().After(entry.ExpiresAt) {
+		atomic.AddInt64(&b.misses, 1)
+		_ = b.Delete(key)
+		return Entry{}, false, nil
+	}
+
+	atomic.AddInt64(&b.hits, 1)
+	return entry, true, nil
+}
+
+func (b *Bolt) Set(key string, entry Entry, ttl time.Duration) error {
+	if ttl > 0 {
+		entry.ExpiresAt = time.Now().Add(ttl)
+	}
+
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(sitemapBucket).Put([]byte(key), body)
+	})
+}
+
+func (b *Bolt) Delete(key string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(sitemapBucket).Delete([]byte(key))
+	})
+}
+
+func (b *Bolt) Stats() Stats {
+	var keys int64
+
+	_ = b.db.View(func(tx *bolt.Tx) error {
+		keys = int64(tx.Bucket(sitemapBucket).Stats().KeyN)
+		return nil
+	})
+
+	return Stats{
+		Hits:   atomic.LoadInt64(&b.hits),
+		Misses: atomic.LoadInt64(&b.misses),
+		Keys:   keys,
+	}
+}
+
+// Close releases the underlying BoltDB file.
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
@@ -0,0 +1,34 @@
+// Package cache defines a pluggable cache interface for sitemap scan
+// results, with backends for in-process memory, the filesystem,
+// BoltDB, and Redis so results can survive a restart or be shared
+// across instances.
+package cache
+
+import "time"
+
+// Entry is what gets stored per cache key: the cached payload plus
+// enough metadata to revalidate it with a conditional GET instead of
+// refetching it outright.
+type Entry struct {
+	Value        []byte    `json:"value"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	StoredAt     time.Time `json:"stored_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// Stats summarizes activity for a cache backend.
+type Stats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	Keys   int64 `json:"keys"`
+}
+
+// SitemapCache is implemented by every cache backend this package
+// ships. Set's ttl of zero means the entry never expires on its own.
+type SitemapCache interface {
+	Get(key string) (Entry, bool, error)
+	Set(key string, entry Entry, ttl time.Duration) error
+	Delete(key string) error
+	Stats() Stats
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// Filesystem is a SitemapCache backed by one JSON file per key under
+// a base directory, so cached results survive a restart.
+type Filesystem struct {
+	dir    string
+	hits   int64
+	misses int64
+}
+
+// NewFilesystem returns a Filesystem cache rooted at dir, creating it
+// if it doesn't already exist.
+func NewFilesystem(dir string) (*Filesystem, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %v", err)
+	}
+	return &Filesystem{dir: dir}, nil
+}
+
+func (f *Filesystem) Get(key string) (Entry, bool, error) {
+	body, err := os.ReadFile(f.path(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			atomic.AddInt64(&f.misses, 1)
+			return Entry{}, false, nil
+		}
+		return Entry{}, false, err
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(body, &entry); err != nil {
+		return Entry{}, false, err
+	}
+
+	if !entry.ExpiresAt.IsZero() && time.Now().After(entry.ExpiresAt) {
+		atomic.AddInt64(&f.misses, 1)
+		_ = f.Delete(key)
+		return Entry{}, false, nil
+	}
+
+	atomic.AddInt64(&f.hits, 1)
+	return entry, true, nil
+}
+
+func (f *Filesystem) Set(key string, entry Entry, ttl time.Duration) error {
+	if ttl > 0 {
+		entry.ExpiresAt = time.Now().Add(ttl)
+	}
+
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(f.path(key), body, 0o644)
+}
+
+func (f *Filesystem) Delete(key string) error {
+	err := os.Remove(f.path(key))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (f *Filesystem) Stats() Stats {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		entries = nil
+	}
+
+	return Stats{
+		Hits:   atomic.LoadInt64(&f.hits),
+		Misses: atomic.LoadInt64(&f.misses),
+		Keys:   int64(len(entries)),
+	}
+}
+
+// path maps a cache key to a file under dir, hashing it so arbitrary
+// keys (URLs, in practice) are always safe file names.
+func (f *Filesystem) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(f.dir, hex.EncodeToString(sum[:])+".json")
+}
@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+
+	gocache "github.com/patrickmn/go-cache"
+)
+
+// Memory is an in-process SitemapCache backed by go-cache. It's the
+// default backend; restarting the process loses everything in it.
+type Memory struct {
+	store  *gocache.Cache
+	hits   int64
+	misses int64
+}
+
+// NewMemory returns a Memory cache that cleans up expired entries
+// every cleanupInterval.
+func NewMemory(cleanupInterval time.Duration) *Memory {
+	return &Memory{store: gocache.New(gocache.NoExpiration, cleanupInterval)}
+}
+
+func (m *Memory) Get(key string) (Entry, bool, error) {
+	value, found := m.store.Get(key)
+	if !found {
+		atomic.AddInt64(&m.misses, 1)
+		return Entry{}, false, nil
+	}
+
+	entry, ok := value.(Entry)
+	if !ok {
+		atomic.AddInt64(&m.misses, 1)
+		return Entry{}, false, nil
+	}
+
+	atomic.AddInt64(&m.hits, 1)
+	return entry, true, nil
+}
+
+func (m *Memory) Set(key string, entry Entry, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = gocache.NoExpiration
+	}
+	m.store.Set(key, entry, ttl)
+	return nil
+}
+
+func (m *Memory) Delete(key string) error {
+	m.store.Delete(key)
+	return nil
+}
+
+func (m *Memory) Stats() Stats {
+	return Stats{
+		Hits:   atomic.LoadInt64(&m.hits),
+		Misses: atomic.LoadInt64(&m.misses),
+		Keys:   int64(m.store.ItemCount()),
+	}
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis is a SitemapCache backed by a Redis server, so cached results
+// can be shared across multiple instances of the service.
+type Redis struct {
+	client *redis.Client
+	prefix string
+	hits   int64
+	misses int64
+}
+
+// NewRedis returns a Redis cache talking to addr, namespacing every
+// key under prefix (e.g. "sitemap_scanner:").
+func NewRedis(addr, prefix string) *Redis {
+	return &Redis{
+		client: redis.NewClient(&redis.Options{Addr: addr}),
+		prefix: prefix,
+	}
+}
+
+func (r *Redis) Get(key string) (Entry, bool, error) {
+	ctx := context.Background()
+
+	body, err := r.client.Get(ctx, r.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		atomic.AddInt64(&r.misses, 1)
+		return Entry{}, false, nil
+	}
+	if err != nil {
+		return Entry{}, false, err
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(body, &entry); err != nil {
+		return Entry{}, false, err
+	}
+
+	atomic.AddInt64(&r.hits, 1)
+	return entry, true, nil
+}
+
+func (r *Redis) Set(key string, entry Entry, ttl time.Duration) error {
+	if ttl > 0 {
+		entry.ExpiresAt = time.Now().Add(ttl)
+	}
+
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return r.client.Set(context.Background(), r.prefix+key, body, ttl).Err()
+}
+
+func (r *Redis) Delete(key string) error {
+	return r.client.Del(context.Background(), r.prefix+key).Err()
+}
+
+func (r *Redis) Stats() Stats {
+	keys, err := r.client.Keys(context.Background(), r.prefix+"*").Result()
+	keyCount := int64(0)
+	if err == nil {
+		keyCount = int64(len(keys))
+	}
+
+	return Stats{
+		Hits:   atomic.LoadInt64(&r.hits),
+		Misses: atomic.LoadInt64(&r.misses),
+		Keys:   keyCount,
+	}
+}
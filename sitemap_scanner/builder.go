@@ -0,0 +1,167 @@
+package sitemapscanner
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// sitemapXMLNS is the namespace every generated urlset/sitemapindex
+// document declares.
+const sitemapXMLNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// Sitemap protocol limits: a single sitemap file may list at most
+// 50,000 URLs and must not exceed 50 MiB uncompressed.
+const (
+	maxURLsPerFile  = 50000
+	maxBytesPerFile = 50 * 1024 * 1024
+)
+
+// BuildOptions configures sitemap/sitemap-index generation.
+type BuildOptions struct {
+	// BaseURL, when generation splits urls across multiple files, is
+	// used to build each file's <loc> in the top-level index that's
+	// appended to the result (e.g. "https://example.com/sitemaps").
+	BaseURL string
+	// Gzip compresses each generated file and appends ".gz" to its name.
+	Gzip bool
+}
+
+// BuildFile is one generated sitemap or sitemap-index file.
+type BuildFile struct {
+	Name string `json:"name"`
+	Data []byte `json:"-"`
+}
+
+// BuildSitemap renders urls into one or more spec-compliant urlset XML
+// files, splitting at the 50,000 URL / 50 MiB sitemap protocol limits.
+// When splitting produces more than one file, a sitemapindex file
+// referencing them is appended to the result; opts.BaseURL is then
+// required, and BuildSitemap returns an error if it is empty. News,
+// image and video entries are written as plain <news>, <image> and
+// <video> elements, without their extension namespaces.
+func BuildSitemap(urls []SitemapURL, opts BuildOptions) ([]BuildFile, error) {
+	batches := batchURLs(urls)
+
+	files := make([]BuildFile, 0, len(batches)+1)
+	for i, batch := range batches {
+		data, err := marshalSitemap(batch)
+		if err != nil {
+			return nil, err
+		}
+
+		name := fmt.Sprintf("sitemap%d.xml", i+1)
+		if len(batches) == 1 {
+			name = "sitemap.xml"
+		}
+
+		file, err := finalizeFile(name, data, opts.Gzip)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	if len(files) > 1 {
+		if opts.BaseURL == "" {
+			return nil, fmt.Errorf("build sitemap: %d URLs split across %d files, BaseURL is required to generate the sitemap index", len(urls), len(files))
+		}
+
+		locs := make([]string, len(files))
+		for i, file := range files {
+			locs[i] = strings.TrimSuffix(opts.BaseURL, "/") + "/" + file.Name
+		}
+
+		indexFile, err := BuildIndex(locs, opts)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, indexFile)
+	}
+
+	return files, nil
+}
+
+// BuildIndex renders a spec-compliant sitemapindex XML file
+// referencing sitemapURLs.
+func BuildIndex(sitemapURLs []string, opts BuildOptions) (BuildFile, error) {
+	index := SitemapIndex{Xmlns: sitemapXMLNS}
+	for _, loc := range sitemapURLs {
+		index.Sitemaps = append(index.Sitemaps, SitemapIndexURL{Location: loc})
+	}
+
+	data, err := xml.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return BuildFile{}, fmt.Errorf("failed to marshal sitemap index: %v", err)
+	}
+	data = append([]byte(xml.Header), data...)
+
+	return finalizeFile("sitemap_index.xml", data, opts.Gzip)
+}
+
+// batchURLs splits urls into groups that each stay under
+// maxURLsPerFile and maxBytesPerFile.
+func batchURLs(urls []SitemapURL) [][]SitemapURL {
+	if len(urls) == 0 {
+		return [][]SitemapURL{{}}
+	}
+
+	var batches [][]SitemapURL
+	var current []SitemapURL
+	currentBytes := 0
+
+	for _, u := range urls {
+		entryBytes, err := xml.Marshal(u)
+		size := 0
+		if err == nil {
+			size = len(entryBytes)
+		}
+
+		if len(current) > 0 && (len(current) >= maxURLsPerFile || currentBytes+size > maxBytesPerFile) {
+			batches = append(batches, current)
+			current = nil
+			currentBytes = 0
+		}
+
+		current = append(current, u)
+		currentBytes += size
+	}
+
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+
+	return batches
+}
+
+// marshalSitemap renders a single urlset XML document.
+func marshalSitemap(urls []SitemapURL) ([]byte, error) {
+	sitemap := Sitemap{Xmlns: sitemapXMLNS, URLs: urls}
+
+	data, err := xml.MarshalIndent(sitemap, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal sitemap: %v", err)
+	}
+
+	return append([]byte(xml.Header), data...), nil
+}
+
+// finalizeFile optionally gzips data, naming the result accordingly.
+func finalizeFile(name string, data []byte, gzipEncode bool) (BuildFile, error) {
+	if !gzipEncode {
+		return BuildFile{Name: name, Data: data}, nil
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		return BuildFile{}, err
+	}
+	if err := gz.Close(); err != nil {
+		return BuildFile{}, err
+	}
+
+	return BuildFile{Name: name + ".gz", Data: buf.Bytes()}, nil
+}
@@ -0,0 +1,168 @@
+package sitemapscanner
+
+import "testing"
+
+func TestParseRobotsTxtGrouping(t *testing.T) {
+	body := []byte(`User-agent: *
+Disallow: /private/
+Crawl-delay: 2
+
+User-agent: sitemap_scanner
+Disallow: /private/
+Allow: /private/public-page.html
+Crawl-delay: 1
+
+Sitemap: https://example.com/sitemap.xml
+`)
+
+	t.Run("exact match preferred over wildcard", func(t *testing.T) {
+		policy := parseRobotsTxt(body, "sitemap_scanner/1.0")
+
+		if policy.CrawlDelay != 1 {
+			t.Errorf("CrawlDelay = %v, want 1 (from the exact-match group, not the wildcard's 2)", policy.CrawlDelay)
+		}
+		if len(policy.Allowed) != 1 || policy.Allowed[0] != "/private/public-page.html" {
+			t.Errorf("Allowed = %v, want [/private/public-page.html]", policy.Allowed)
+		}
+		if len(policy.Disallowed) != 1 || policy.Disallowed[0] != "/private/" {
+			t.Errorf("Disallowed = %v, want [/private/]", policy.Disallowed)
+		}
+	})
+
+	t.Run("falls back to wildcard for unmatched agent", func(t *testing.T) {
+		policy := parseRobotsTxt(body, "some-other-bot/2.0")
+
+		if policy.CrawlDelay != 2 {
+			t.Errorf("CrawlDelay = %v, want 2 (from the wildcard group)", policy.CrawlDelay)
+		}
+		if len(policy.Allowed) != 0 {
+			t.Errorf("Allowed = %v, want none", policy.Allowed)
+		}
+		if len(policy.Disallowed) != 1 || policy.Disallowed[0] != "/private/" {
+			t.Errorf("Disallowed = %v, want [/private/]", policy.Disallowed)
+		}
+	})
+
+	t.Run("sitemap directives collected regardless of group", func(t *testing.T) {
+		policy := parseRobotsTxt(body, "sitemap_scanner/1.0")
+
+		if len(policy.SitemapURLs) != 1 || policy.SitemapURLs[0] != "https://example.com/sitemap.xml" {
+			t.Errorf("SitemapURLs = %v, want [https://example.com/sitemap.xml]", policy.SitemapURLs)
+		}
+	})
+}
+
+func TestParseRobotsTxtNoMatchingGroup(t *testing.T) {
+	body := []byte(`User-agent: some-other-bot
+Disallow: /
+`)
+
+	policy := parseRobotsTxt(body, "sitemap_scanner/1.0")
+
+	if len(policy.Allowed) != 0 || len(policy.Disallowed) != 0 {
+		t.Errorf("policy = %+v, want an empty (allow-everything) policy when no group matches and there's no wildcard", policy)
+	}
+}
+
+func TestParseRobotsTxtMultipleAgentsPerGroup(t *testing.T) {
+	body := []byte(`User-agent: botA
+User-agent: sitemap_scanner
+Disallow: /no-bots/
+`)
+
+	policy := parseRobotsTxt(body, "sitemap_scanner/1.0")
+
+	if len(policy.Disallowed) != 1 || policy.Disallowed[0] != "/no-bots/" {
+		t.Errorf("Disallowed = %v, want [/no-bots/] (group listing multiple User-agent lines should still match)", policy.Disallowed)
+	}
+}
+
+func TestSelectRobotsGroup(t *testing.T) {
+	wildcard := &robotsGroup{agents: []string{"*"}}
+	exact := &robotsGroup{agents: []string{"sitemap_scanner"}}
+	groups := []*robotsGroup{wildcard, exact}
+
+	tests := []struct {
+		name      string
+		userAgent string
+		want      *robotsGroup
+	}{
+		{"exact match wins over wildcard", "sitemap_scanner/1.0", exact},
+		{"case-insensitive match", "Sitemap_Scanner/1.0", exact},
+		{"falls back to wildcard", "curl/8.0", wildcard},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectRobotsGroup(groups, tt.userAgent); got != tt.want {
+				t.Errorf("selectRobotsGroup() = %p, want %p", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectRobotsGroupNoGroups(t *testing.T) {
+	if got := selectRobotsGroup(nil, "sitemap_scanner/1.0"); got != nil {
+		t.Errorf("selectRobotsGroup(nil, ...) = %v, want nil", got)
+	}
+}
+
+func TestRobotsPolicyAllows(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RobotsPolicy
+		url    string
+		want   bool
+	}{
+		{
+			name:   "no rules allows everything",
+			policy: RobotsPolicy{},
+			url:    "https://example.com/anything",
+			want:   true,
+		},
+		{
+			name:   "matching disallow blocks",
+			policy: RobotsPolicy{Disallowed: []string{"/private/"}},
+			url:    "https://example.com/private/page",
+			want:   false,
+		},
+		{
+			name:   "non-matching disallow doesn't block",
+			policy: RobotsPolicy{Disallowed: []string{"/private/"}},
+			url:    "https://example.com/public/page",
+			want:   true,
+		},
+		{
+			name:   "longer allow overrides shorter disallow",
+			policy: RobotsPolicy{Disallowed: []string{"/private/"}, Allowed: []string{"/private/public.html"}},
+			url:    "https://example.com/private/public.html",
+			want:   true,
+		},
+		{
+			name:   "longer disallow overrides shorter allow",
+			policy: RobotsPolicy{Allowed: []string{"/"}, Disallowed: []string{"/private/"}},
+			url:    "https://example.com/private/secret.html",
+			want:   false,
+		},
+		{
+			name:   "same-length allow and disallow ties to allow",
+			policy: RobotsPolicy{Allowed: []string{"/page"}, Disallowed: []string{"/page"}},
+			url:    "https://example.com/page",
+			want:   true,
+		},
+		{
+			name:   "query string is matched as part of the path",
+			policy: RobotsPolicy{Disallowed: []string{"/search?q="}},
+			url:    "https://example.com/search?q=term",
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.allows(tt.url); got != tt.want {
+				t.Errorf("allows(%q) = %v, want %v", tt.url, got, tt.want)
+			}
+		})
+	}
+}
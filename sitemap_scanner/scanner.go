@@ -1,34 +1,85 @@
 package sitemapscanner
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/xml"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// gzipMagic is the two-byte header that identifies a gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// DefaultUserAgent is the User-Agent sent to robots.txt and sitemap
+// requests when the caller doesn't configure one of its own.
+const DefaultUserAgent = "sitemap_scanner/1.0"
+
 // SitemapURL represents a URL entry in the sitemap
 type SitemapURL struct {
-	SiteMapIndexURL string `json:"sitemap"`
-	Location        string `json:"loc" xml:"loc"`
-	LastModified    string `json:"lastmod,omitempty" xml:"lastmod,omitempty"`
-	ChangeFreq      string `json:"changefreq,omitempty" xml:"changefreq,omitempty"`
-	Priority        string `json:"priority,omitempty" xml:"priority,omitempty"`
+	SiteMapIndexURL string      `json:"sitemap" xml:"-"`
+	Location        string      `json:"loc" xml:"loc"`
+	LastModified    string      `json:"lastmod,omitempty" xml:"lastmod,omitempty"`
+	ChangeFreq      string      `json:"changefreq,omitempty" xml:"changefreq,omitempty"`
+	Priority        string      `json:"priority,omitempty" xml:"priority,omitempty"`
+	News            *NewsInfo   `json:"news,omitempty" xml:"news"`
+	Images          []ImageInfo `json:"images,omitempty" xml:"image"`
+	Videos          []VideoInfo `json:"videos,omitempty" xml:"video"`
+}
+
+// NewsInfo holds the fields from the Google News sitemap extension
+// (the `news:` namespace).
+type NewsInfo struct {
+	Publication     NewsPublication `json:"publication" xml:"publication"`
+	PublicationDate string          `json:"publication_date,omitempty" xml:"publication_date,omitempty"`
+	Title           string          `json:"title,omitempty" xml:"title,omitempty"`
+}
+
+// NewsPublication identifies the publication a news sitemap entry
+// belongs to.
+type NewsPublication struct {
+	Name     string `json:"name,omitempty" xml:"name,omitempty"`
+	Language string `json:"language,omitempty" xml:"language,omitempty"`
+}
+
+// ImageInfo holds the fields from the image sitemap extension (the
+// `image:` namespace).
+type ImageInfo struct {
+	Location string `json:"loc" xml:"loc"`
+	Caption  string `json:"caption,omitempty" xml:"caption,omitempty"`
+	Title    string `json:"title,omitempty" xml:"title,omitempty"`
+}
+
+// VideoInfo holds the fields from the video sitemap extension (the
+// `video:` namespace).
+type VideoInfo struct {
+	ThumbnailLoc string `json:"thumbnail_loc,omitempty" xml:"thumbnail_loc,omitempty"`
+	Title        string `json:"title,omitempty" xml:"title,omitempty"`
+	Description  string `json:"description,omitempty" xml:"description,omitempty"`
+	ContentLoc   string `json:"content_loc,omitempty" xml:"content_loc,omitempty"`
+	PlayerLoc    string `json:"player_loc,omitempty" xml:"player_loc,omitempty"`
+	Duration     int    `json:"duration,omitempty" xml:"duration,omitempty"`
 }
 
 // Sitemap represents the sitemap structure
 type Sitemap struct {
 	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `json:"-" xml:"xmlns,attr,omitempty"`
 	URLs    []SitemapURL `json:"urls" xml:"url"`
 }
 
 // SitemapIndex represents a sitemap index file
 type SitemapIndex struct {
 	XMLName  xml.Name          `xml:"sitemapindex"`
+	Xmlns    string            `json:"-" xml:"xmlns,attr,omitempty"`
 	Sitemaps []SitemapIndexURL `json:"sitemaps" xml:"sitemap"`
 }
 
@@ -40,13 +91,147 @@ type SitemapIndexURL struct {
 
 // SitemapResult represents the final result
 type SitemapResult struct {
-	URLs     []SitemapURL `json:"urls"`
-	Sitemaps []string     `json:"sitemap_urls"`
-	Error    string       `json:"error,omitempty"`
+	URLs         []SitemapURL             `json:"urls"`
+	Sitemaps     []string                 `json:"sitemap_urls"`
+	Robots       RobotsPolicy             `json:"robots"`
+	Stats        []SitemapFetchStat       `json:"stats,omitempty"`
+	Revalidation map[string]CachedSitemap `json:"revalidation,omitempty"`
+	Error        string                   `json:"error,omitempty"`
+}
+
+// SitemapFetchStat records what happened when fetching a single
+// sitemap or sitemap-index URL.
+type SitemapFetchStat struct {
+	URL          string `json:"url"`
+	Status       int    `json:"status,omitempty"`
+	Bytes        int    `json:"bytes,omitempty"`
+	DurationMS   int64  `json:"duration_ms"`
+	NotModified  bool   `json:"not_modified,omitempty"`
+	Error        string `json:"error,omitempty"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// CachedSitemap is enough of a previous fetch of one sitemap URL to
+// revalidate it cheaply with a conditional GET next time, reusing its
+// URLs as-is on a 304 instead of re-parsing the body.
+type CachedSitemap struct {
+	ETag         string       `json:"etag,omitempty"`
+	LastModified string       `json:"last_modified,omitempty"`
+	URLs         []SitemapURL `json:"urls,omitempty"`
+}
+
+// GetSitemapOptions configures how GetSitemap crawls a site's
+// sitemaps.
+type GetSitemapOptions struct {
+	// UserAgent is used for robots.txt matching and outbound requests.
+	UserAgent string
+	// Workers caps how many sitemaps are fetched concurrently.
+	Workers int
+	// MaxDepth caps how many levels of sitemap index nesting are followed.
+	MaxDepth int
+	// MaxURLs caps how many URLs are collected in total. Zero means unlimited.
+	MaxURLs int
+	// Timeout bounds each individual sitemap fetch.
+	Timeout time.Duration
+	// Filter narrows which URL entries are kept, and lets whole sitemap
+	// index entries be skipped without being fetched.
+	Filter SitemapFilter
+	// Prior holds CachedSitemap entries from a previous GetSitemap call,
+	// keyed by sitemap URL, so unchanged sitemaps can be revalidated with
+	// a conditional GET instead of being re-fetched and re-parsed.
+	Prior map[string]CachedSitemap
+}
+
+// SitemapFilter narrows which URL entries GetSitemap returns. A zero
+// value keeps everything.
+type SitemapFilter struct {
+	// Since and Until bound lastmod. A sitemap index entry whose lastmod
+	// falls entirely outside this window is skipped without being fetched.
+	Since, Until time.Time
+	// PathRegex, if set, must match the URL's path.
+	PathRegex *regexp.Regexp
+	// MinPriority, if greater than zero, is the lowest priority kept.
+	MinPriority float64
+	// ChangeFreqIn, if non-empty, restricts entries to these changefreq values.
+	ChangeFreqIn []string
+}
+
+// DefaultGetSitemapOptions returns the options GetSitemap uses when the
+// caller doesn't provide any of its own.
+func DefaultGetSitemapOptions() GetSitemapOptions {
+	return GetSitemapOptions{
+		UserAgent: DefaultUserAgent,
+		Workers:   4,
+		MaxDepth:  5,
+		Timeout:   30 * time.Second,
+	}
+}
+
+// withDefaults fills in zero-valued fields with DefaultGetSitemapOptions.
+func (o GetSitemapOptions) withDefaults() GetSitemapOptions {
+	defaults := DefaultGetSitemapOptions()
+
+	if o.UserAgent == "" {
+		o.UserAgent = defaults.UserAgent
+	}
+	if o.Workers <= 0 {
+		o.Workers = defaults.Workers
+	}
+	if o.MaxDepth <= 0 {
+		o.MaxDepth = defaults.MaxDepth
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = defaults.Timeout
+	}
+
+	return o
+}
+
+// RobotsPolicy describes what robots.txt allows or denies for the
+// user agent that was used to fetch it, so callers can see why a
+// sitemap-listed URL was skipped.
+type RobotsPolicy struct {
+	UserAgent   string   `json:"user_agent"`
+	Allowed     []string `json:"allowed,omitempty"`
+	Disallowed  []string `json:"disallowed,omitempty"`
+	CrawlDelay  float64  `json:"crawl_delay,omitempty"`
+	SitemapURLs []string `json:"sitemap_urls,omitempty"`
+}
+
+// robotsRule is a single Allow/Disallow path rule within a robots.txt
+// group.
+type robotsRule struct {
+	path  string
+	allow bool
+}
+
+// robotsGroup holds the rules that apply to one or more User-agent
+// names, in the order they appeared in robots.txt.
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay float64
 }
 
 // GetSitemap retrieves sitemap data by first checking robots.txt and returns it as JSON
 func GetSitemap(targetURL string) (SitemapResult, error) {
+	return GetSitemapWithOptions(targetURL, DefaultGetSitemapOptions())
+}
+
+// GetSitemapWithUserAgent behaves like GetSitemap but lets the caller
+// choose the User-Agent used for robots.txt matching and outbound
+// requests.
+func GetSitemapWithUserAgent(targetURL, userAgent string) (SitemapResult, error) {
+	opts := DefaultGetSitemapOptions()
+	opts.UserAgent = userAgent
+	return GetSitemapWithOptions(targetURL, opts)
+}
+
+// GetSitemapWithOptions crawls a site's sitemaps with a bounded worker
+// pool, honoring opts.MaxDepth and opts.MaxURLs and breaking cycles
+// between sitemap indexes that reference each other or themselves.
+func GetSitemapWithOptions(targetURL string, opts GetSitemapOptions) (SitemapResult, error) {
 	// Parse the target URL
 	parsedURL, err := url.Parse(targetURL)
 	if err != nil {
@@ -58,10 +243,13 @@ func GetSitemap(targetURL string) (SitemapResult, error) {
 		parsedURL.Scheme = "https"
 	}
 
+	opts = opts.withDefaults()
+
 	baseURL := fmt.Sprintf("%s://%s", parsedURL.Scheme, parsedURL.Host)
 
-	// Get sitemap URLs from robots.txt
-	sitemapURLs, err := getSitemapURLsFromRobots(baseURL)
+	// Get the robots.txt policy, including any sitemap URLs it lists
+	policy, err := fetchRobotsPolicy(baseURL, opts.UserAgent)
+	sitemapURLs := policy.SitemapURLs
 	if err != nil || len(sitemapURLs) == 0 {
 		// Fallback to common sitemap locations
 		sitemapURLs = []string{
@@ -71,112 +259,564 @@ func GetSitemap(targetURL string) (SitemapResult, error) {
 		}
 	}
 
-	var allURLs []SitemapURL
-	var validSitemaps []string
+	c := newSitemapCrawl(opts, policy)
+	defer c.close()
 
-	// Process each sitemap URL
+	var wg sync.WaitGroup
 	for _, sitemapURL := range sitemapURLs {
-		urls, err := processSitemap(sitemapURL)
-		if err == nil && len(urls) > 0 {
-			allURLs = append(allURLs, urls...)
-			validSitemaps = append(validSitemaps, sitemapURL)
+		if !policy.allows(sitemapURL) {
+			continue
 		}
+
+		wg.Add(1)
+		go c.crawl(sitemapURL, 0, &wg)
 	}
+	wg.Wait()
 
 	result := SitemapResult{
-		URLs:     allURLs,
-		Sitemaps: validSitemaps,
+		URLs:         c.urls,
+		Sitemaps:     c.sitemaps,
+		Robots:       policy,
+		Stats:        c.stats,
+		Revalidation: c.revalidation,
 	}
 
-	if len(allURLs) == 0 {
+	if len(result.URLs) == 0 {
 		result.Error = "No sitemap data found"
 	}
 
 	return result, nil
 }
 
-// getSitemapURLsFromRobots fetches robots.txt and extracts sitemap URLs
-func getSitemapURLsFromRobots(baseURL string) ([]string, error) {
+// allows reports whether the policy permits fetching rawURL, matching
+// on the URL path the same way robots.txt itself does.
+func (p RobotsPolicy) allows(rawURL string) bool {
+	path := rawURL
+	if parsed, err := url.Parse(rawURL); err == nil && parsed.Path != "" {
+		path = parsed.Path
+		if parsed.RawQuery != "" {
+			path += "?" + parsed.RawQuery
+		}
+	}
+
+	// The longest matching rule wins; ties go to Allow.
+	matched := ""
+	allowed := true
+	for _, rule := range p.Disallowed {
+		if rule != "" && strings.HasPrefix(path, rule) && len(rule) >= len(matched) {
+			matched = rule
+			allowed = false
+		}
+	}
+	for _, rule := range p.Allowed {
+		if rule != "" && strings.HasPrefix(path, rule) && len(rule) >= len(matched) {
+			matched = rule
+			allowed = true
+		}
+	}
+
+	return allowed
+}
+
+// fetchRobotsPolicy fetches robots.txt and builds the RobotsPolicy
+// that applies to userAgent.
+func fetchRobotsPolicy(baseURL, userAgent string) (RobotsPolicy, error) {
 	robotsURL := baseURL + "/robots.txt"
 
 	client := &http.Client{
 		Timeout: 10 * time.Second,
 	}
 
-	resp, err := client.Get(robotsURL)
+	req, err := http.NewRequest(http.MethodGet, robotsURL, nil)
 	if err != nil {
-		return nil, err
+		return RobotsPolicy{}, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return RobotsPolicy{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("robots.txt not found: %d", resp.StatusCode)
+		return RobotsPolicy{}, fmt.Errorf("robots.txt not found: %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return RobotsPolicy{}, err
+	}
+
+	return parseRobotsTxt(body, userAgent), nil
+}
+
+// parseRobotsTxt groups robots.txt by User-agent and returns the rules
+// that apply to userAgent, falling back to the wildcard "*" group.
+func parseRobotsTxt(body []byte, userAgent string) RobotsPolicy {
+	policy := RobotsPolicy{UserAgent: userAgent}
+
+	var groups []*robotsGroup
+	var current *robotsGroup
+	sawRule := false
+
+	for _, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		field := strings.ToLower(strings.TrimSpace(parts[0]))
+		value := strings.TrimSpace(parts[1])
+
+		switch field {
+		case "user-agent":
+			if current == nil || sawRule {
+				current = &robotsGroup{}
+				groups = append(groups, current)
+				sawRule = false
+			}
+			current.agents = append(current.agents, value)
+		case "disallow":
+			sawRule = true
+			if current != nil {
+				current.rules = append(current.rules, robotsRule{path: value, allow: false})
+			}
+		case "allow":
+			sawRule = true
+			if current != nil {
+				current.rules = append(current.rules, robotsRule{path: value, allow: true})
+			}
+		case "crawl-delay":
+			sawRule = true
+			if current != nil {
+				if delay, err := strconv.ParseFloat(value, 64); err == nil {
+					current.crawlDelay = delay
+				}
+			}
+		case "sitemap":
+			policy.SitemapURLs = append(policy.SitemapURLs, value)
+		}
+	}
+
+	group := selectRobotsGroup(groups, userAgent)
+	if group == nil {
+		return policy
+	}
+
+	policy.CrawlDelay = group.crawlDelay
+	for _, rule := range group.rules {
+		if rule.allow {
+			policy.Allowed = append(policy.Allowed, rule.path)
+		} else {
+			policy.Disallowed = append(policy.Disallowed, rule.path)
+		}
+	}
+
+	return policy
+}
+
+// selectRobotsGroup picks the first group with a User-agent name that
+// appears (case-insensitively) within userAgent, so "sitemap_scanner"
+// matches "sitemap_scanner/1.0", and otherwise falls back to the
+// wildcard "*" group.
+func selectRobotsGroup(groups []*robotsGroup, userAgent string) *robotsGroup {
+	userAgent = strings.ToLower(userAgent)
+
+	var wildcard *robotsGroup
+	for _, group := range groups {
+		for _, agent := range group.agents {
+			agent = strings.ToLower(agent)
+			if agent == "*" {
+				wildcard = group
+			}
+			if agent != "*" && strings.Contains(userAgent, agent) {
+				return group
+			}
+		}
+	}
+
+	return wildcard
+}
+
+// isGzipSitemap reports whether body is a gzip-compressed sitemap. The
+// magic bytes are authoritative whenever there's enough body to check:
+// http.Client already transparently decompresses responses sent with
+// Content-Encoding: gzip, so a `.gz` URL suffix or a gzip Content-Type
+// can both be stale by the time body reaches us. Only fall back to
+// those hints when body is too short to sniff.
+func isGzipSitemap(body []byte, contentType, sitemapURL string) bool {
+	if len(body) >= len(gzipMagic) {
+		return bytes.Equal(body[:len(gzipMagic)], gzipMagic)
+	}
+	if strings.Contains(contentType, "gzip") {
+		return true
+	}
+	return strings.HasSuffix(strings.ToLower(sitemapURL), ".gz")
+}
+
+// decompressGzip decompresses a gzip-compressed sitemap body.
+func decompressGzip(body []byte) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
+	defer gz.Close()
+
+	return io.ReadAll(gz)
+}
+
+// sitemapCrawl coordinates a bounded pool of sitemap fetches, dedups
+// visited sitemap URLs to break cycles, and collects the results.
+type sitemapCrawl struct {
+	opts     GetSitemapOptions
+	policy   RobotsPolicy
+	sem      chan struct{}
+	throttle *time.Ticker
+
+	visited  sync.Map
+	urlCount int64
+
+	mu           sync.Mutex
+	urls         []SitemapURL
+	sitemaps     []string
+	stats        []SitemapFetchStat
+	revalidation map[string]CachedSitemap
+}
+
+func newSitemapCrawl(opts GetSitemapOptions, policy RobotsPolicy) *sitemapCrawl {
+	c := &sitemapCrawl{
+		opts:   opts,
+		policy: policy,
+		sem:    make(chan struct{}, opts.Workers),
+	}
 
-	// Extract sitemap URLs using regex
-	sitemapRegex := regexp.MustCompile(`(?i)sitemap:\s*(.+)`)
-	matches := sitemapRegex.FindAllStringSubmatch(string(body), -1)
+	// A single ticker shared by every fetch, regardless of which branch
+	// of the crawl tree it's in, so Crawl-Delay bounds the actual
+	// request rate to the site instead of just the gap between
+	// siblings spawned from the same loop.
+	if policy.CrawlDelay > 0 {
+		c.throttle = time.NewTicker(time.Duration(policy.CrawlDelay * float64(time.Second)))
+	}
 
-	var sitemapURLs []string
-	for _, match := range matches {
-		if len(match) > 1 {
-			sitemapURL := strings.TrimSpace(match[1])
-			sitemapURLs = append(sitemapURLs, sitemapURL)
+	return c
+}
+
+// close releases resources held by the crawl, such as the
+// Crawl-Delay ticker.
+func (c *sitemapCrawl) close() {
+	if c.throttle != nil {
+		c.throttle.Stop()
+	}
+}
+
+// crawl fetches sitemapURL and, for sitemap indexes, spawns one
+// goroutine per child sitemap, respecting MaxDepth, MaxURLs, and the
+// worker semaphore. It must be called as `go c.crawl(...)` with wg
+// already incremented.
+func (c *sitemapCrawl) crawl(sitemapURL string, depth int, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	if depth > c.opts.MaxDepth {
+		return
+	}
+	if _, alreadyVisited := c.visited.LoadOrStore(sitemapURL, struct{}{}); alreadyVisited {
+		return
+	}
+	if c.opts.MaxURLs > 0 && atomic.LoadInt64(&c.urlCount) >= int64(c.opts.MaxURLs) {
+		return
+	}
+
+	c.sem <- struct{}{}
+	defer func() { <-c.sem }()
+
+	if c.throttle != nil {
+		<-c.throttle.C
+	}
+
+	prior := c.opts.Prior[sitemapURL]
+
+	start := time.Now()
+	urls, children, stat, err := fetchSitemap(sitemapURL, c.opts.UserAgent, c.opts.Timeout, c.policy, prior)
+	stat.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		stat.Error = err.Error()
+	}
+
+	c.mu.Lock()
+	c.stats = append(c.stats, stat)
+	if err == nil {
+		c.sitemaps = append(c.sitemaps, sitemapURL)
+		// urls holds every robots-allowed entry regardless of which
+		// CachedSitemap it came from, so the query filter is applied
+		// here on every call instead of baked into the cached/decoded
+		// result — otherwise a 304 revalidation (or a cache entry from
+		// an earlier request with a different filter) would return
+		// entries matching someone else's filter instead of this one.
+		for _, u := range urls {
+			if !filterAllowsURL(c.opts.Filter, u) {
+				continue
+			}
+			if c.opts.MaxURLs > 0 && len(c.urls) >= c.opts.MaxURLs {
+				break
+			}
+			c.urls = append(c.urls, u)
 		}
+		atomic.StoreInt64(&c.urlCount, int64(len(c.urls)))
+
+		if len(children) == 0 {
+			if c.revalidation == nil {
+				c.revalidation = make(map[string]CachedSitemap)
+			}
+			c.revalidation[sitemapURL] = CachedSitemap{
+				ETag:         stat.ETag,
+				LastModified: stat.LastModified,
+				URLs:         urls,
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	if err != nil {
+		return
+	}
+
+	for _, child := range children {
+		if !c.policy.allows(child.URL) {
+			continue
+		}
+		if !filterAllowsWindow(c.opts.Filter, child.LastModified) {
+			continue
+		}
+		wg.Add(1)
+		go c.crawl(child.URL, depth+1, wg)
 	}
+}
 
-	return sitemapURLs, nil
+// sitemapIndexEntry is a sitemap reference found while streaming a
+// sitemap index, carrying enough of SitemapIndexURL to prune by lastmod
+// before it's fetched.
+type sitemapIndexEntry struct {
+	URL          string
+	LastModified string
 }
 
-// processSitemap fetches and parses a sitemap XML file
-func processSitemap(sitemapURL string) ([]SitemapURL, error) {
+// fetchSitemap fetches and parses a single sitemap XML file. For a
+// sitemap index it returns the child sitemap entries; for a leaf
+// sitemap it returns the URL entries allowed by policy. The query
+// SitemapFilter is deliberately not applied here: the caller applies
+// it to the returned urls, so the same CachedSitemap entry can be
+// revalidated and reused across calls with different filters.
+// When prior has an ETag or Last-Modified, it's sent as a conditional
+// GET; a 304 response reuses prior.URLs instead of re-fetching.
+func fetchSitemap(sitemapURL, userAgent string, timeout time.Duration, policy RobotsPolicy, prior CachedSitemap) ([]SitemapURL, []sitemapIndexEntry, SitemapFetchStat, error) {
+	stat := SitemapFetchStat{URL: sitemapURL}
+
 	client := &http.Client{
-		Timeout: 30 * time.Second,
+		Timeout: timeout,
 	}
 
-	resp, err := client.Get(sitemapURL)
+	req, err := http.NewRequest(http.MethodGet, sitemapURL, nil)
 	if err != nil {
-		return nil, err
+		return nil, nil, stat, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if prior.ETag != "" {
+		req.Header.Set("If-None-Match", prior.ETag)
+	}
+	if prior.LastModified != "" {
+		req.Header.Set("If-Modified-Since", prior.LastModified)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, stat, err
 	}
 	defer resp.Body.Close()
 
+	stat.Status = resp.StatusCode
+	stat.ETag = resp.Header.Get("ETag")
+	stat.LastModified = resp.Header.Get("Last-Modified")
+
+	if resp.StatusCode == http.StatusNotModified {
+		stat.NotModified = true
+		if stat.ETag == "" {
+			stat.ETag = prior.ETag
+		}
+		if stat.LastModified == "" {
+			stat.LastModified = prior.LastModified
+		}
+		return prior.URLs, nil, stat, nil
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("sitemap not found: %d", resp.StatusCode)
+		return nil, nil, stat, fmt.Errorf("sitemap not found: %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, nil, stat, err
+	}
+	stat.Bytes = len(body)
+
+	if isGzipSitemap(body, resp.Header.Get("Content-Type"), sitemapURL) {
+		body, err = decompressGzip(body)
+		if err != nil {
+			return nil, nil, stat, fmt.Errorf("failed to decompress gzip sitemap: %v", err)
+		}
 	}
 
-	// Try to parse as sitemap index first
-	var sitemapIndex SitemapIndex
-	if err := xml.Unmarshal(body, &sitemapIndex); err == nil && len(sitemapIndex.Sitemaps) > 0 {
-		// This is a sitemap index, process each sitemap
-		var allURLs []SitemapURL
-		for _, indexSitemap := range sitemapIndex.Sitemaps {
-			urls, err := processSitemap(indexSitemap.Location)
-			if err == nil {
-				allURLs = append(allURLs, urls...)
+	urls, children, err := decodeSitemap(body, sitemapURL, policy)
+	if err != nil {
+		return nil, nil, stat, err
+	}
+
+	return urls, children, stat, nil
+}
+
+// decodeSitemap streams the <url>/<sitemap> elements out of body
+// instead of unmarshaling the whole document at once, applying policy
+// to each <url> entry as it's decoded. The query SitemapFilter is
+// applied by the caller instead, so the decoded/cached result isn't
+// tied to one particular filter.
+func decodeSitemap(body []byte, sitemapURL string, policy RobotsPolicy) ([]SitemapURL, []sitemapIndexEntry, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+
+	var urls []SitemapURL
+	var children []sitemapIndexEntry
+	isIndex := false
+
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse sitemap XML: %v", err)
+		}
+
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch se.Name.Local {
+		case "sitemapindex":
+			isIndex = true
+		case "sitemap":
+			var entry SitemapIndexURL
+			if err := decoder.DecodeElement(&entry, &se); err != nil {
+				return nil, nil, fmt.Errorf("failed to parse sitemap XML: %v", err)
+			}
+			children = append(children, sitemapIndexEntry{URL: entry.Location, LastModified: entry.LastModified})
+		case "url":
+			var entry SitemapURL
+			if err := decoder.DecodeElement(&entry, &se); err != nil {
+				return nil, nil, fmt.Errorf("failed to parse sitemap XML: %v", err)
+			}
+			if !policy.allows(entry.Location) {
+				continue
 			}
+			entry.SiteMapIndexURL = sitemapURL
+			urls = append(urls, entry)
 		}
-		return allURLs, nil
 	}
 
-	// Try to parse as regular sitemap
-	var sitemap Sitemap
-	if err := xml.Unmarshal(body, &sitemap); err != nil {
-		return nil, fmt.Errorf("failed to parse sitemap XML: %v", err)
+	if isIndex {
+		return nil, children, nil
+	}
+
+	return urls, nil, nil
+}
+
+// filterAllowsWindow reports whether a sitemap index entry's lastmod
+// falls inside filter's Since/Until window; entries whose lastmod is
+// before Since or after Until are not fetched. An unparseable or
+// missing lastmod is kept, since its contents are unknown until fetched.
+func filterAllowsWindow(filter SitemapFilter, lastmod string) bool {
+	if filter.Since.IsZero() && filter.Until.IsZero() {
+		return true
+	}
+
+	t, err := parseLastModified(lastmod)
+	if err != nil {
+		return true
 	}
 
-	for i, _ := range sitemap.URLs {
-		sitemap.URLs[i].SiteMapIndexURL = sitemapURL
+	return inWindow(filter, t)
+}
+
+// filterAllowsURL reports whether a <url> entry matches filter.
+func filterAllowsURL(filter SitemapFilter, entry SitemapURL) bool {
+	if !filter.Since.IsZero() || !filter.Until.IsZero() {
+		t, err := parseLastModified(entry.LastModified)
+		if err == nil && !inWindow(filter, t) {
+			return false
+		}
+	}
+
+	if filter.PathRegex != nil {
+		path := entry.Location
+		if parsed, err := url.Parse(entry.Location); err == nil && parsed.Path != "" {
+			path = parsed.Path
+		}
+		if !filter.PathRegex.MatchString(path) {
+			return false
+		}
+	}
+
+	if filter.MinPriority > 0 {
+		priority, err := strconv.ParseFloat(entry.Priority, 64)
+		if err != nil || priority < filter.MinPriority {
+			return false
+		}
+	}
+
+	if len(filter.ChangeFreqIn) > 0 {
+		match := false
+		for _, freq := range filter.ChangeFreqIn {
+			if strings.EqualFold(freq, entry.ChangeFreq) {
+				match = true
+				break
+			}
+		}
+		if !match {
+			return false
+		}
+	}
+
+	return true
+}
+
+// inWindow reports whether t falls within filter's Since/Until bounds.
+func inWindow(filter SitemapFilter, t time.Time) bool {
+	if !filter.Since.IsZero() && t.Before(filter.Since) {
+		return false
+	}
+	if !filter.Until.IsZero() && t.After(filter.Until) {
+		return false
+	}
+	return true
+}
+
+// parseLastModified parses a sitemap lastmod value, which is either a
+// full W3C datetime or a bare date.
+func parseLastModified(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("empty lastmod")
+	}
+
+	layouts := []string{time.RFC3339, "2006-01-02"}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
 	}
 
-	return sitemap.URLs, nil
+	return time.Time{}, fmt.Errorf("unrecognized lastmod format: %q", value)
 }
@@ -0,0 +1,170 @@
+package sitemapscanner
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+)
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatalf("gzip.Write: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("gzip.Close: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestIsGzipSitemap(t *testing.T) {
+	plain := []byte(`<?xml version="1.0"?><urlset></urlset>`)
+	compressed := gzipBytes(t, plain)
+
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		sitemapURL  string
+		want        bool
+	}{
+		{"gzip magic bytes", compressed, "", "https://example.com/sitemap", true},
+		{"plain body despite .gz suffix", plain, "", "https://example.com/sitemap.xml.gz", false},
+		{"plain body despite gzip content-type", plain, "application/gzip", "https://example.com/sitemap.xml", false},
+		{"short body falls back to suffix", []byte("x"), "", "https://example.com/sitemap.xml.gz", true},
+		{"short body falls back to content-type", []byte("x"), "application/gzip", "https://example.com/sitemap.xml", true},
+		{"short plain body", []byte("x"), "", "https://example.com/sitemap.xml", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isGzipSitemap(tt.body, tt.contentType, tt.sitemapURL); got != tt.want {
+				t.Errorf("isGzipSitemap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecompressGzip(t *testing.T) {
+	want := []byte(`<?xml version="1.0"?><urlset><url><loc>https://example.com/</loc></url></urlset>`)
+	compressed := gzipBytes(t, want)
+
+	got, err := decompressGzip(compressed)
+	if err != nil {
+		t.Fatalf("decompressGzip() error = %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("decompressGzip() = %q, want %q", got, want)
+	}
+}
+
+func TestDecodeSitemapNewsExtension(t *testing.T) {
+	body := []byte(`<?xml version="1.0"?>
+<urlset xmlns:news="http://www.google.com/schemas/sitemap-news/0.9">
+	<url>
+		<loc>https://example.com/articles/1</loc>
+		<news:news>
+			<news:publication>
+				<news:name>Example Times</news:name>
+				<news:language>en</news:language>
+			</news:publication>
+			<news:publication_date>2026-07-01</news:publication_date>
+			<news:title>Breaking Example</news:title>
+		</news:news>
+	</url>
+</urlset>`)
+
+	urls, children, err := decodeSitemap(body, "https://example.com/news-sitemap.xml", RobotsPolicy{})
+	if err != nil {
+		t.Fatalf("decodeSitemap() error = %v", err)
+	}
+	if children != nil {
+		t.Fatalf("decodeSitemap() children = %v, want nil", children)
+	}
+	if len(urls) != 1 {
+		t.Fatalf("decodeSitemap() returned %d urls, want 1", len(urls))
+	}
+
+	news := urls[0].News
+	if news == nil {
+		t.Fatal("News = nil, want populated NewsInfo")
+	}
+	if news.Publication.Name != "Example Times" || news.Publication.Language != "en" {
+		t.Errorf("News.Publication = %+v, unexpected", news.Publication)
+	}
+	if news.Title != "Breaking Example" {
+		t.Errorf("News.Title = %q, want %q", news.Title, "Breaking Example")
+	}
+}
+
+func TestDecodeSitemapImageAndVideoExtensions(t *testing.T) {
+	body := []byte(`<?xml version="1.0"?>
+<urlset
+	xmlns:image="http://www.google.com/schemas/sitemap-image/1.1"
+	xmlns:video="http://www.google.com/schemas/sitemap-video/1.1">
+	<url>
+		<loc>https://example.com/gallery/1</loc>
+		<image:image>
+			<image:loc>https://example.com/photo1.jpg</image:loc>
+			<image:caption>First photo</image:caption>
+		</image:image>
+		<image:image>
+			<image:loc>https://example.com/photo2.jpg</image:loc>
+		</image:image>
+		<video:video>
+			<video:thumbnail_loc>https://example.com/thumb.jpg</video:thumbnail_loc>
+			<video:title>Demo</video:title>
+			<video:content_loc>https://example.com/demo.mp4</video:content_loc>
+			<video:duration>120</video:duration>
+		</video:video>
+	</url>
+</urlset>`)
+
+	urls, _, err := decodeSitemap(body, "https://example.com/media-sitemap.xml", RobotsPolicy{})
+	if err != nil {
+		t.Fatalf("decodeSitemap() error = %v", err)
+	}
+	if len(urls) != 1 {
+		t.Fatalf("decodeSitemap() returned %d urls, want 1", len(urls))
+	}
+
+	entry := urls[0]
+	if len(entry.Images) != 2 {
+		t.Fatalf("Images = %d entries, want 2", len(entry.Images))
+	}
+	if entry.Images[0].Location != "https://example.com/photo1.jpg" || entry.Images[0].Caption != "First photo" {
+		t.Errorf("Images[0] = %+v, unexpected", entry.Images[0])
+	}
+
+	if len(entry.Videos) != 1 {
+		t.Fatalf("Videos = %d entries, want 1", len(entry.Videos))
+	}
+	if entry.Videos[0].Duration != 120 || entry.Videos[0].ContentLoc != "https://example.com/demo.mp4" {
+		t.Errorf("Videos[0] = %+v, unexpected", entry.Videos[0])
+	}
+}
+
+func TestDecodeSitemapIndex(t *testing.T) {
+	body := []byte(`<?xml version="1.0"?>
+<sitemapindex>
+	<sitemap>
+		<loc>https://example.com/sitemap-news.xml</loc>
+		<lastmod>2026-07-01</lastmod>
+	</sitemap>
+</sitemapindex>`)
+
+	urls, children, err := decodeSitemap(body, "https://example.com/sitemap-index.xml", RobotsPolicy{})
+	if err != nil {
+		t.Fatalf("decodeSitemap() error = %v", err)
+	}
+	if urls != nil {
+		t.Fatalf("decodeSitemap() urls = %v, want nil", urls)
+	}
+	if len(children) != 1 || children[0].URL != "https://example.com/sitemap-news.xml" {
+		t.Errorf("decodeSitemap() children = %+v, unexpected", children)
+	}
+}